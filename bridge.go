@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// bridgeGrace is how long \hup waits for a bridged child to exit on its own
+// before it is force-killed.
+const bridgeGrace = 3 * time.Second
+
+// bridgeMaxMsgSize is the read-limit floor applied while bridging, mirroring
+// the limit in the classic gorilla/websocket "command" example. It only
+// raises the limit when the dial-time configured size is smaller -- it never
+// lowers a larger, user-configured -max-msg below this floor.
+const bridgeMaxMsgSize = 8192
+
+// bridgeFraming selects how a bridged child's stdout/stderr are chunked
+// into outbound websocket frames, and how inbound frames are delimited on
+// the child's stdin.
+type bridgeFraming int
+
+const (
+	BridgeFramingLine bridgeFraming = iota
+	BridgeFramingNull
+	BridgeFramingSize
+)
+
+func parseBridgeFraming(sz string) (bridgeFraming, error) {
+	switch sz {
+	case "", "line":
+		return BridgeFramingLine, nil
+	case "null":
+		return BridgeFramingNull, nil
+	case "size":
+		return BridgeFramingSize, nil
+	}
+	return BridgeFramingLine, fmt.Errorf("unrecognized bridge framing %q", sz)
+}
+
+// bridge pipes websocket messages to/from a child process's stdio, per the
+// classic gorilla/websocket "command" example: inbound frames go to the
+// child's stdin, and each line (or null/size-delimited chunk) the child
+// writes to stdout/stderr goes out as a TextMessage.
+type bridge struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	framing bridgeFraming
+
+	chDone chan struct{} // closed once the child process has exited
+}
+
+// startBridge spawns szCmd with args, wires its stdout/stderr to
+// pH.WriteMessage, and returns a bridge whose writeIn method feeds inbound
+// websocket traffic to the child's stdin. maxMsgSize is the dial-time
+// configured inbound size cap -- it's only raised to bridgeMaxMsgSize when
+// smaller, never lowered.
+func startBridge(pH *Handler, szCmd string, args []string, framing bridgeFraming, maxMsgSize int64) (*bridge, error) {
+
+	cmd := exec.Command(szCmd, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	if pC := pH.GetConn(); pC != nil {
+		if maxMsgSize == 0 {
+			maxMsgSize = DefaultMaxMsgSize
+		}
+		limit := int64(bridgeMaxMsgSize)
+		if maxMsgSize > limit {
+			limit = maxMsgSize
+		}
+		pC.SetReadLimit(limit)
+	}
+
+	pb := &bridge{
+		cmd:     cmd,
+		stdin:   stdin,
+		framing: framing,
+		chDone:  make(chan struct{}),
+	}
+
+	pb.pump(pH, stdout)
+	pb.pump(pH, stderr)
+
+	// child exit tears down the websocket side
+	go func() {
+		err := cmd.Wait()
+		fnErr("BRIDGE EXIT", err)
+		close(pb.chDone)
+		pH.Close()
+	}()
+
+	// a websocket close (explicit \hup, or the read pump exiting on its
+	// own after a remote drop) tears down the child side, mirroring the
+	// goroutine above
+	chCloseAll, chRdrDone := pH.chCloseAll, pH.chRdrDone
+	go func() {
+		select {
+		case <-chCloseAll:
+		case <-chRdrDone:
+		}
+		pb.stop(bridgeGrace)
+	}()
+
+	return pb, nil
+}
+
+// pump relays rdr to pH.WriteMessage as TextMessage frames, split
+// according to pb.framing.
+func (pb *bridge) pump(pH *Handler, rdr io.Reader) {
+	go func() {
+		switch pb.framing {
+
+		case BridgeFramingNull:
+			scn := bufio.NewScanner(rdr)
+			scn.Split(splitNull)
+			for scn.Scan() {
+				pH.WriteMessage(TextMessage, scn.Bytes())
+			}
+
+		case BridgeFramingSize:
+			brdr := bufio.NewReader(rdr)
+			for {
+				var n uint32
+				if err := binary.Read(brdr, binary.BigEndian, &n); err != nil {
+					return
+				}
+				buf := make([]byte, n)
+				if _, err := io.ReadFull(brdr, buf); err != nil {
+					return
+				}
+				pH.WriteMessage(TextMessage, buf)
+			}
+
+		default: // BridgeFramingLine
+			scn := bufio.NewScanner(rdr)
+			for scn.Scan() {
+				pH.WriteMessage(TextMessage, scn.Bytes())
+			}
+		}
+	}()
+}
+
+// splitNull is a bufio.SplitFunc that tokenizes on NUL bytes.
+func splitNull(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[0:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// writeIn sends an inbound websocket message to the child's stdin.
+func (pb *bridge) writeIn(bs []byte) error {
+
+	if pb.framing == BridgeFramingSize {
+		var hdr [4]byte
+		binary.BigEndian.PutUint32(hdr[:], uint32(len(bs)))
+		if _, err := pb.stdin.Write(hdr[:]); err != nil {
+			return err
+		}
+		_, err := pb.stdin.Write(bs)
+		return err
+	}
+
+	if _, err := pb.stdin.Write(bs); err != nil {
+		return err
+	}
+
+	term := byte('\n')
+	if pb.framing == BridgeFramingNull {
+		term = 0
+	}
+	_, err := pb.stdin.Write([]byte{term})
+	return err
+}
+
+// stop closes the child's stdin and waits up to grace for it to exit
+// before force-killing it.
+func (pb *bridge) stop(grace time.Duration) {
+	if pb.stdin != nil {
+		pb.stdin.Close()
+	}
+	select {
+	case <-pb.chDone:
+	case <-time.After(grace):
+		if pb.cmd.Process != nil {
+			pb.cmd.Process.Kill()
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestParseBridgeFraming(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    bridgeFraming
+		wantErr bool
+	}{
+		{"", BridgeFramingLine, false},
+		{"line", BridgeFramingLine, false},
+		{"null", BridgeFramingNull, false},
+		{"size", BridgeFramingSize, false},
+		{"bogus", BridgeFramingLine, true},
+	} {
+		got, err := parseBridgeFraming(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseBridgeFraming(%q) error = %v, wantErr %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && got != tc.want {
+			t.Errorf("parseBridgeFraming(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSplitNull(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		data       string
+		atEOF      bool
+		wantAdv    int
+		wantToken  string
+		wantErr    bool
+		wantNoMore bool
+	}{
+		{"one token", "abc\x00def", false, 4, "abc", false, false},
+		{"no terminator, not EOF", "abc", false, 0, "", false, true},
+		{"no terminator, at EOF", "abc", true, 3, "abc", false, false},
+		{"empty at EOF", "", true, 0, "", false, false},
+	} {
+		advance, token, err := splitNull([]byte(tc.data), tc.atEOF)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if tc.wantNoMore {
+			if advance != 0 || token != nil {
+				t.Errorf("%s: got advance=%d token=%q, want no token yet", tc.name, advance, token)
+			}
+			continue
+		}
+		if advance != tc.wantAdv || string(token) != tc.wantToken {
+			t.Errorf("%s: got advance=%d token=%q, want advance=%d token=%q", tc.name, advance, token, tc.wantAdv, tc.wantToken)
+		}
+	}
+}
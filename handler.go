@@ -2,6 +2,7 @@
 package main
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 	"sync"
@@ -25,6 +26,14 @@ const (
 	PongMessage   = gws.PongMessage
 )
 
+// CloseNormalClosure (1000) is the status code \close sends when none is
+// given.
+const CloseNormalClosure = gws.CloseNormalClosure
+
+// DefaultMaxMsgSize caps inbound message size when HandlerOptions.MaxMsgSize
+// isn't set, matching the classic gorilla/websocket example.
+const DefaultMaxMsgSize = 8 * 1024 * 1024
+
 func (err HandlerErr) Error() string {
 	switch err {
 	case ErrNilWs:
@@ -45,13 +54,78 @@ type Handler struct {
 	chCloseAll chan struct{}
 
 	mtxWri sync.Mutex // setup + write mutex
+
+	// OnConnect, when set, fires once the Handler can write, just before
+	// the read/ping pumps are started. A reconnect supervisor can use this slot to re-queue an
+	// initial subscription/auth message after every successful (re)dial.
+	OnConnect func(*Handler) error
+
+	// PingFn sends an application-level ping on each pingInterval tick.
+	// Defaults to a websocket PingMessage control frame.
+	PingFn func(*Handler) error
+
+	// PongFn reports whether payload (of the given message type) is an
+	// application-level pong -- e.g. a text frame containing "pong" from
+	// a server that doesn't speak control-frame pings. A true result is
+	// swallowed by the read pump (after resetting the read deadline)
+	// instead of being passed to MsgReaderFunc. Defaults to recognizing
+	// none, since control-frame pongs are already handled separately.
+	PongFn func(msgType int, payload []byte) bool
 }
 
+// HandlerOptions bundles StartHandler's optional callbacks and settings.
+type HandlerOptions struct {
+	OnConnect func(*Handler) error
+	PingFn    func(*Handler) error
+	PongFn    func(msgType int, payload []byte) bool
+
+	// MaxMsgSize caps inbound message size via Conn.SetReadLimit. 0 uses
+	// DefaultMaxMsgSize.
+	MaxMsgSize int64
+}
+
+func defaultPingFn(ss *Handler) error {
+	return ss.WriteMessage(gws.PingMessage, nil)
+}
+
+func defaultPongFn(_ int, _ []byte) bool {
+	return false
+}
+
+// MsgReaderFunc handles one inbound websocket message, or a terminal read
+// error (err != nil), and reports whether the read pump should keep going.
+// The return value only governs what happens after a successful read --
+// on a read error the pump always stops once fnHandleMsg returns, since by
+// then NextReader/ReadAll has already failed and there's nothing left to
+// read from.
 type MsgReaderFunc func(pHdl *Handler, nType int, iRdr io.Reader, err error) bool
 
+// DialOptions configures permessage-deflate compression negotiation for
+// Dial. A zero-value DialOptions dials without requesting compression, as
+// before.
+type DialOptions struct {
+	EnableCompression bool
+	CompressionLevel  int // per-message override; 0 leaves the library default
+}
+
 // Dial returns a new websocket.Conn for the given websocket URL.
-func Dial(url string, hdr http.Header) (*gws.Conn, *http.Response, error) {
-	return gws.DefaultDialer.Dial(url, hdr)
+func Dial(url string, hdr http.Header, opts DialOptions) (*gws.Conn, *http.Response, error) {
+
+	dialer := *gws.DefaultDialer
+	dialer.EnableCompression = opts.EnableCompression
+
+	pC, pRsp, err := dialer.Dial(url, hdr)
+	if err != nil {
+		return pC, pRsp, err
+	}
+
+	if opts.CompressionLevel != 0 {
+		if err := pC.SetCompressionLevel(opts.CompressionLevel); err != nil {
+			return pC, pRsp, err
+		}
+	}
+
+	return pC, pRsp, nil
 }
 
 // GetConn returns the Handler's underlying websocket.Conn.
@@ -94,6 +168,17 @@ func (ss *Handler) Close() error {
 	return ss.wsConn.Close()
 }
 
+// CloseWithCode sends a formatted close frame (code + reason) before
+// closing the underlying connection, rather than closing it abruptly.
+func (ss *Handler) CloseWithCode(code int, reason string) error {
+	errWri := ss.WriteMessage(gws.CloseMessage, gws.FormatCloseMessage(code, reason))
+	errClose := ss.Close()
+	if errWri != nil {
+		return errWri
+	}
+	return errClose
+}
+
 // WriteMessage to the Handler's websocket
 func (ss *Handler) WriteMessage(nType int, bsMsg []byte) error {
 
@@ -112,11 +197,28 @@ func (ss *Handler) WriteMessage(nType int, bsMsg []byte) error {
 	return ss.wsConn.WriteMessage(nType, bsMsg)
 }
 
+// SetCompression toggles per-message write compression on the Handler's
+// websocket. It only takes effect if permessage-deflate was negotiated at
+// dial time via DialOptions.EnableCompression.
+func (ss *Handler) SetCompression(enable bool) error {
+
+	if ss.wsConn == nil {
+		return ErrNilWs
+	}
+
+	ss.mtxWri.Lock()
+	defer ss.mtxWri.Unlock()
+
+	ss.wsConn.EnableWriteCompression(enable)
+	return nil
+}
+
 // StartHandler creates 'read' and 'ping' message-pump goroutines
 func StartHandler(
 	pwsConn *gws.Conn,
 	pingInterval, readTimeout, writeTimeout time.Duration,
 	fnHandleMsg MsgReaderFunc,
+	opts HandlerOptions,
 ) (*Handler, error) {
 
 	if pwsConn == nil {
@@ -129,11 +231,34 @@ func StartHandler(
 
 	ss.dlRead = readTimeout
 	ss.dlWrite = writeTimeout
+	ss.OnConnect = opts.OnConnect
+
+	ss.PingFn = opts.PingFn
+	if ss.PingFn == nil {
+		ss.PingFn = defaultPingFn
+	}
+	ss.PongFn = opts.PongFn
+	if ss.PongFn == nil {
+		ss.PongFn = defaultPongFn
+	}
+
+	maxMsgSize := opts.MaxMsgSize
+	if maxMsgSize == 0 {
+		maxMsgSize = DefaultMaxMsgSize
+	}
+	pwsConn.SetReadLimit(maxMsgSize)
 
 	ss.chCloseAll = make(chan struct{})
 	ss.chRdrDone = make(chan struct{})
 	ss.chRdrErr = make(chan error, 1)
 
+	if ss.OnConnect != nil {
+		if err := ss.OnConnect(ss); err != nil {
+			pwsConn.Close()
+			return nil, err
+		}
+	}
+
 	// read pump
 	go func() {
 
@@ -156,10 +281,33 @@ func StartHandler(
 						return
 					}
 				}
+
 				var mtype int
 				var iRdr io.Reader
 				mtype, iRdr, err = ss.wsConn.NextReader()
-				if !fnHandleMsg(ss, mtype, iRdr, err) {
+				if err != nil {
+					fnHandleMsg(ss, mtype, iRdr, err)
+					return
+				}
+
+				var bsMsg []byte
+				if bsMsg, err = io.ReadAll(iRdr); err != nil {
+					fnHandleMsg(ss, mtype, bytes.NewReader(nil), err)
+					return
+				}
+
+				// application-level pong: reset the read deadline and
+				// swallow it before it reaches fnHandleMsg
+				if ss.PongFn(mtype, bsMsg) {
+					if ss.dlRead > 0 {
+						if err = ss.wsConn.SetReadDeadline(time.Now().Add(ss.dlRead)); err != nil {
+							return
+						}
+					}
+					continue
+				}
+
+				if !fnHandleMsg(ss, mtype, bytes.NewReader(bsMsg), nil) {
 					return
 				}
 			}
@@ -191,7 +339,7 @@ func StartHandler(
 					// stop on read pump termination
 					return
 				case _ = <-ticker.C:
-					if err = ss.WriteMessage(gws.PingMessage, nil); err != nil {
+					if err = ss.PingFn(ss); err != nil {
 						return
 					}
 				}
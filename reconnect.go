@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	gws "github.com/gorilla/websocket"
+)
+
+// ReconnectOptions configures the backoff used between re-dial attempts.
+type ReconnectOptions struct {
+	BaseDelay   time.Duration
+	Factor      float64
+	MaxDelay    time.Duration
+	MaxAttempts int // 0 = unlimited
+}
+
+// DefaultReconnectOptions are sane defaults for a flaky websocket link.
+var DefaultReconnectOptions = ReconnectOptions{
+	BaseDelay: 500 * time.Millisecond,
+	Factor:    2,
+	MaxDelay:  30 * time.Second,
+}
+
+// delay returns the (jittered) backoff for the given zero-based attempt.
+func (ro ReconnectOptions) delay(attempt int) time.Duration {
+	d := float64(ro.BaseDelay) * math.Pow(ro.Factor, float64(attempt))
+	if maxD := float64(ro.MaxDelay); ro.MaxDelay > 0 && d > maxD {
+		d = maxD
+	}
+	d *= 0.5 + rand.Float64()*0.5 // jitter to 50%-100% of the computed delay
+	return time.Duration(d)
+}
+
+// startSupervisor spawns a goroutine that watches the current connection
+// for a non-normal-close exit and, while pf.reconnectOn, re-dials
+// pf.szDialURL with exponential backoff. It owns the lifecycle across the
+// series of *gws.Conn instances a single \dial may end up producing, and is
+// the sole caller of Handler.Wait() for as long as it runs.
+func (pf *fsm) startSupervisor() {
+	pf.stopSupervisor()
+	chStop := make(chan struct{})
+	chDone := make(chan struct{})
+	pf.chSupervisor = chStop
+	pf.chSupervisorDone = chDone
+	go func() {
+		defer close(chDone)
+		pf.superviseLoop(chStop)
+	}()
+}
+
+// stopSupervisor signals the supervisor goroutine (if any) to stop, and
+// returns a channel that closes once it has actually exited. Callers that
+// are about to call Handler.Close()/Wait() themselves on the handler the
+// supervisor is watching must wait on that channel first, since Wait() is
+// not safe to call from two goroutines at once.
+func (pf *fsm) stopSupervisor() <-chan struct{} {
+	chStop := pf.chSupervisor
+	chDone := pf.chSupervisorDone
+	pf.chSupervisor = nil
+	pf.chSupervisorDone = nil
+	if chStop != nil {
+		close(chStop)
+	}
+	return chDone
+}
+
+func (pf *fsm) superviseLoop(chStop chan struct{}) {
+
+	attempt := 0
+	for {
+		pf.mtxPH.Lock()
+		pH := pf.pH
+		pf.mtxPH.Unlock()
+		if pH == nil {
+			return
+		}
+
+		// a stopped supervisor returns without calling pH.Wait(), so it
+		// never waits alongside its caller or a newer supervisor
+		select {
+		case <-chStop:
+			return
+		case <-pH.chRdrDone:
+		}
+
+		errRdr, _ := pH.Wait()
+
+		select {
+		case <-chStop:
+			return
+		default:
+		}
+
+		if errRdr == nil || gws.IsCloseError(errRdr, gws.CloseNormalClosure, gws.CloseGoingAway) {
+			return
+		}
+		if !pf.reconnectOn {
+			return
+		}
+
+		// a bridge is tied to the dead connection -- its own watchdog has
+		// already torn down the child process, but pf.pBridge itself is
+		// only cleared here, before redialing. \bridge does not re-arm
+		// itself across a reconnect; re-issue it once back online if the
+		// child should keep running.
+		pf.stopBridge()
+
+		// keep re-dialing with backoff until one succeeds, reconnectOn is
+		// cleared, MaxAttempts is hit, or the supervisor is stopped --
+		// falling through to the top of the outer loop on a failed dial
+		// would read a still-nil pf.pH and exit instead of retrying.
+		for {
+			if pf.reconnectOpts.MaxAttempts > 0 && attempt >= pf.reconnectOpts.MaxAttempts {
+				fnErr("RECONNECT", fmt.Errorf("giving up after %d attempts: %w", attempt, errRdr))
+				return
+			}
+
+			d := pf.reconnectOpts.delay(attempt)
+			attempt++
+			fmt.Printf("\x1b[93mRECONNECT in %s (attempt %d): %s\x1b[0m\n", d, attempt, errRdr)
+
+			select {
+			case <-chStop:
+				return
+			case <-time.After(d):
+			}
+
+			pf.mtxPH.Lock()
+			err := pf.connect(pf.szDialURL)
+			pf.mtxPH.Unlock()
+			if err == nil {
+				attempt = 0
+				break
+			}
+			fnErr("RECONNECT DIAL", err)
+			if !pf.reconnectOn {
+				return
+			}
+		}
+	}
+}
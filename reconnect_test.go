@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectOptionsDelay(t *testing.T) {
+	ro := ReconnectOptions{
+		BaseDelay: 100 * time.Millisecond,
+		Factor:    2,
+		MaxDelay:  time.Second,
+	}
+
+	for _, tc := range []struct {
+		attempt       int
+		wantMin       time.Duration
+		wantMaxUncapd time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond, 200 * time.Millisecond},
+		{2, 200 * time.Millisecond, 400 * time.Millisecond},
+	} {
+		d := ro.delay(tc.attempt)
+		if d < tc.wantMin || d > tc.wantMaxUncapd {
+			t.Errorf("attempt %d: delay %s outside jittered range [%s, %s]", tc.attempt, d, tc.wantMin, tc.wantMaxUncapd)
+		}
+	}
+}
+
+func TestReconnectOptionsDelayCapsAtMaxDelay(t *testing.T) {
+	ro := ReconnectOptions{
+		BaseDelay: 100 * time.Millisecond,
+		Factor:    2,
+		MaxDelay:  500 * time.Millisecond,
+	}
+
+	// attempt 10 would be 100ms * 2^10 uncapped -- must not exceed MaxDelay
+	d := ro.delay(10)
+	if d > ro.MaxDelay {
+		t.Errorf("delay %s exceeds MaxDelay %s", d, ro.MaxDelay)
+	}
+}
+
+func TestReconnectOptionsDelayUnlimited(t *testing.T) {
+	ro := ReconnectOptions{BaseDelay: time.Second, Factor: 2} // MaxDelay: 0 -> unlimited
+	d := ro.delay(5)
+	if want := 32 * time.Second; d < want/2 || d > want {
+		t.Errorf("delay %s outside jittered range for uncapped attempt 5 (want up to %s)", d, want)
+	}
+}
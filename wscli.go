@@ -2,14 +2,17 @@ package main
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"regexp"
 	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -44,38 +47,248 @@ func errfunc(prfx string, err error, bAbort bool) {
 	}
 }
 
+// msgAction selects what happens to a \msg-family heredoc body once its
+// terminator line is seen.
+type msgAction int
+
+const (
+	msgActionSend      msgAction = iota // send the body as a TextMessage
+	msgActionOnConnect                  // stash the body as the \onconnect message
+	msgActionBinary                     // send the raw body as a BinaryMessage
+	msgActionBinaryHex                  // hex-decode the body, send as BinaryMessage
+	msgActionBinaryB64                  // base64-decode the body, send as BinaryMessage
+)
+
 type fsm struct {
-	pH       *Handler
-	hdr      http.Header
-	msg      []byte
-	msgEnd   []byte
-	rxMsgEnd *regexp.Regexp
-	printTs  bool
-	logWri   io.Writer
+	pH        *Handler // guarded by mtxPH below -- read getPH()'s doc comment
+	hdr       http.Header
+	msg       []byte
+	msgEnd    []byte
+	msgAction msgAction
+	printTs   bool
+	logWri    io.Writer
+	binFmt    string // raw|hex|base64 rendering of inbound BinaryMessage frames
+
+	pBridge       *bridge // also guarded by mtxPH: ReadMsg reads it from the read-pump goroutine
+	bridgeFraming bridgeFraming
+
+	// reconnect supervision. mtxPH guards every field the interactive
+	// command loop and the supervisor goroutine can both touch across a
+	// reconnect cycle -- pH, pBridge, szDialURL, onConnectMsg, and
+	// compressOn -- since connect() reads/writes them from whichever
+	// goroutine re-dials.
+	mtxPH            sync.Mutex
+	szDialURL        string
+	onConnectMsg     []byte
+	reconnectOn      bool
+	reconnectOpts    ReconnectOptions
+	chSupervisor     chan struct{}
+	chSupervisorDone chan struct{}
+
+	// application-level ping/pong payloads (empty -> control frames). Set
+	// once from flags before any goroutine starts, so unlike compressOn
+	// they need no lock.
+	pingText string
+	pongText string
+
+	// permessage-deflate compression. compressOn is toggled at runtime by
+	// \compress and read by connect() on reconnect, so it's guarded by
+	// mtxPH; compressLevel, like pingText/pongText, is flag-only.
+	compressOn    bool
+	compressLevel int
+
+	// inbound message size cap (0 -> DefaultMaxMsgSize). Flag-only, like
+	// compressLevel.
+	maxMsgSize int64
 }
 
 func newFsm() *fsm {
 	return &fsm{
-		hdr:      make(http.Header),
-		msg:      make([]byte, 0, 4096),
-		rxMsgEnd: regexp.MustCompile(`\\msg\s+(.*)`),
+		hdr:           make(http.Header),
+		msg:           make([]byte, 0, 4096),
+		reconnectOpts: DefaultReconnectOptions,
+		binFmt:        "raw",
 	}
 }
 
 func (pf *fsm) closeWait() {
-	if pf.pH != nil {
-		err := pf.pH.Close()
-		fnErr("ws close", err)
+	pf.stopBridge()
+
+	// the supervisor, if any, is the sole caller of pH.Wait() for the
+	// active handler -- stop it and join before waiting here ourselves.
+	chDone := pf.stopSupervisor()
+
+	pf.mtxPH.Lock()
+	pH := pf.pH
+	pf.pH = nil
+	pf.mtxPH.Unlock()
 
-		pf.pH.Wait()
-		pf.pH = nil
+	if pH != nil {
+		fnErr("ws close", pH.Close())
+	}
+	pf.waitDone(pH, chDone)
+}
+
+// closeWaitWithCode tears down the active connection like closeWait, but
+// sends a formatted close frame (via Handler.CloseWithCode) instead of
+// closing the transport abruptly.
+func (pf *fsm) closeWaitWithCode(code int, reason string) {
+	pf.stopBridge()
+	chDone := pf.stopSupervisor()
+
+	pf.mtxPH.Lock()
+	pH := pf.pH
+	pf.pH = nil
+	pf.mtxPH.Unlock()
+
+	if pH != nil {
+		fnErr("ws close", pH.CloseWithCode(code, reason))
+	}
+	pf.waitDone(pH, chDone)
+}
+
+// waitDone blocks until pH's pumps (or the reconnect supervisor standing in
+// for them) have exited, then resets any in-progress heredoc.
+func (pf *fsm) waitDone(pH *Handler, chDone <-chan struct{}) {
+	if chDone != nil {
+		<-chDone
+	} else if pH != nil {
+		pH.Wait()
 	}
 	pf.resetMsg()
 }
 
+// connect dials url and, on success, replaces pf.pH with the new
+// connection. Callers that hold pf.mtxPH (the reconnect supervisor) call
+// this directly; other callers should go through closeWait()+connect() so
+// any prior connection, bridge, and supervisor are torn down first.
+func (pf *fsm) connect(url string) error {
+	pC, wsRsp, err := Dial(url, pf.hdr, DialOptions{
+		EnableCompression: pf.compressOn,
+		CompressionLevel:  pf.compressLevel,
+	})
+	if err != nil {
+		if wsRsp != nil {
+			return fmt.Errorf("[%s] %w", wsRsp.Status, err)
+		}
+		return err
+	}
+
+	pH, err := StartHandler(pC, 10*time.Second, 0, 0, pf.ReadMsg, HandlerOptions{
+		OnConnect:  pf.fireOnConnect,
+		PingFn:     pf.pingFn,
+		PongFn:     pf.pongFn,
+		MaxMsgSize: pf.maxMsgSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	pf.pH = pH
+	pf.szDialURL = url
+	return nil
+}
+
+// getPH returns the active handler, synchronized against the reconnect
+// supervisor's writes to pf.pH across a (re)dial.
+func (pf *fsm) getPH() *Handler {
+	pf.mtxPH.Lock()
+	defer pf.mtxPH.Unlock()
+	return pf.pH
+}
+
+// fireOnConnect sends the registered \onconnect message (if any) once a
+// (re)dial succeeds.
+func (pf *fsm) fireOnConnect(pH *Handler) error {
+	if len(pf.onConnectMsg) == 0 {
+		return nil
+	}
+	return pH.WriteMessage(TextMessage, pf.onConnectMsg)
+}
+
+// pingFn sends pf.pingText as a TextMessage if set, otherwise falls back
+// to a standard PingMessage control frame.
+func (pf *fsm) pingFn(pH *Handler) error {
+	if len(pf.pingText) == 0 {
+		return pH.WriteMessage(PingMessage, nil)
+	}
+	return pH.WriteMessage(TextMessage, []byte(pf.pingText))
+}
+
+// pongFn recognizes a text frame matching pf.pongText as an
+// application-level pong. With pf.pongText unset, nothing matches here --
+// control-frame pongs are handled independently.
+func (pf *fsm) pongFn(nType int, bsMsg []byte) bool {
+	if len(pf.pongText) == 0 {
+		return false
+	}
+	return nType == TextMessage && string(bsMsg) == pf.pongText
+}
+
+// startBridge spawns szCmd as a child process and bridges its stdio to the
+// active websocket connection (see \bridge in the help text).
+func (pf *fsm) startBridge(szCmd string, args []string) error {
+	pH := pf.getPH()
+	if pH == nil {
+		return fmt.Errorf("not connected")
+	}
+	pf.stopBridge()
+
+	pb, err := startBridge(pH, szCmd, args, pf.bridgeFraming, pf.maxMsgSize)
+	if err != nil {
+		return err
+	}
+	pf.mtxPH.Lock()
+	pf.pBridge = pb
+	pf.mtxPH.Unlock()
+	return nil
+}
+
+// stopBridge tears down the active bridge (if any), giving its child
+// process bridgeGrace to exit before force-killing it. pf.pBridge is
+// guarded by mtxPH since ReadMsg reads it from the read-pump goroutine.
+func (pf *fsm) stopBridge() {
+	pf.mtxPH.Lock()
+	pb := pf.pBridge
+	pf.pBridge = nil
+	pf.mtxPH.Unlock()
+	if pb != nil {
+		pb.stop(bridgeGrace)
+	}
+}
+
 func (pf *fsm) resetMsg() {
 	pf.msg = pf.msg[:0]
 	pf.msgEnd = nil
+	pf.msgAction = msgActionSend
+}
+
+// beginMsgHeredoc starts a \msg-family heredoc. szTerm is whatever
+// followed the command token (e.g. "\bmsg-hex THE_END" -> "THE_END"),
+// already unparsed -- trailing whitespace is trimmed and, if nothing is
+// left, the heredoc falls back to its default blank-line terminator.
+// action selects what happens to the assembled body once that terminator
+// is seen (see msgAction).
+func (pf *fsm) beginMsgHeredoc(szTerm []byte, action msgAction) {
+	pf.resetMsg()
+	pf.msgAction = action
+	term := bytes.TrimSpace(szTerm)
+	pf.msgEnd = make([]byte, len(term)+1)
+	copy(pf.msgEnd, term)
+	pf.msgEnd[len(term)] = '\n'
+}
+
+// decodeMsgBody returns pf.msg decoded per pf.msgAction, for the binary
+// heredoc variants.
+func (pf *fsm) decodeMsgBody() ([]byte, error) {
+	switch pf.msgAction {
+	case msgActionBinaryHex:
+		return hex.DecodeString(string(bytes.TrimSpace(pf.msg)))
+	case msgActionBinaryB64:
+		return base64.StdEncoding.DecodeString(string(bytes.TrimSpace(pf.msg)))
+	default:
+		return pf.msg, nil
+	}
 }
 
 func (pf *fsm) processLine(line []byte) {
@@ -98,14 +311,36 @@ func (pf *fsm) processLine(line []byte) {
 
 		if bytes.Equal(line, pf.msgEnd) {
 
-			// fmt.Printf("SEND %#v\n", string(pf.msg))
-			if pf.pH != nil {
-				err := pf.pH.WriteMessage(TextMessage, pf.msg)
+			switch pf.msgAction {
+
+			case msgActionOnConnect:
+				pf.mtxPH.Lock()
+				pf.onConnectMsg = append(pf.onConnectMsg[:0], pf.msg...)
+				pf.mtxPH.Unlock()
+				fmt.Print("\x1b[96m", "ONCONNECT MSG SET", "\x1b[0m\n")
+
+			case msgActionBinary, msgActionBinaryHex, msgActionBinaryB64:
+				bsOut, err := pf.decodeMsgBody()
 				if err != nil {
-					fnErr("WS WRITE", err)
-					return
+					fnErr("BMSG DECODE", err)
+				} else if pH := pf.getPH(); pH != nil {
+					if err := pH.WriteMessage(BinaryMessage, bsOut); err != nil {
+						fnErr("WS WRITE", err)
+						return
+					}
+					fmt.Print("\x1b[96m", "SENT", "\x1b[0m\n")
+				}
+
+			default: // msgActionSend
+				if pH := pf.getPH(); pH != nil {
+					// fmt.Printf("SEND %#v\n", string(pf.msg))
+					err := pH.WriteMessage(TextMessage, pf.msg)
+					if err != nil {
+						fnErr("WS WRITE", err)
+						return
+					}
+					fmt.Print("\x1b[96m", "SENT", "\x1b[0m\n")
 				}
-				fmt.Print("\x1b[96m", "SENT", "\x1b[0m\n")
 			}
 
 			pf.resetMsg()
@@ -121,17 +356,25 @@ func (pf *fsm) processLine(line []byte) {
 
 	switch {
 
+	// begin heredoc for the message re-sent after every successful (re)dial
+	case bytes.HasPrefix(line, []byte("\\onconnect \\msg")):
+		pf.beginMsgHeredoc(bytes.TrimPrefix(line, []byte("\\onconnect \\msg")), msgActionOnConnect)
+
 	// begin message heredoc
 	case bytes.HasPrefix(line, []byte("\\msg")):
-		pf.resetMsg()
-		sMtch := pf.rxMsgEnd.FindSubmatch(line)
-		if len(sMtch) < 2 {
-			pf.msgEnd = nil
-		} else {
-			pf.msgEnd = make([]byte, len(sMtch[1]))
-			copy(pf.msgEnd, sMtch[1])
-		}
-		pf.msgEnd = append(pf.msgEnd, '\n')
+		pf.beginMsgHeredoc(bytes.TrimPrefix(line, []byte("\\msg")), msgActionSend)
+
+	// begin binary heredoc, body hex-decoded before sending
+	case bytes.HasPrefix(line, []byte("\\bmsg-hex")):
+		pf.beginMsgHeredoc(bytes.TrimPrefix(line, []byte("\\bmsg-hex")), msgActionBinaryHex)
+
+	// begin binary heredoc, body base64-decoded before sending
+	case bytes.HasPrefix(line, []byte("\\bmsg-b64")):
+		pf.beginMsgHeredoc(bytes.TrimPrefix(line, []byte("\\bmsg-b64")), msgActionBinaryB64)
+
+	// begin binary heredoc, body sent as-is
+	case bytes.HasPrefix(line, []byte("\\bmsg")):
+		pf.beginMsgHeredoc(bytes.TrimPrefix(line, []byte("\\bmsg")), msgActionBinary)
 
 	// clear headers
 	case bytes.HasPrefix(line, []byte("\\hdrclr")):
@@ -153,6 +396,24 @@ func (pf *fsm) processLine(line []byte) {
 	case bytes.HasPrefix(line, []byte("\\hup")):
 		pf.closeWait()
 
+	// graceful close: send a formatted close frame, then hang up
+	case bytes.HasPrefix(line, []byte("\\close")):
+		if pf.getPH() == nil {
+			return
+		}
+		code, reason := CloseNormalClosure, ""
+		fields := strings.Fields(string(bytes.TrimPrefix(line, []byte("\\close"))))
+		if len(fields) > 0 {
+			n, err := strconv.Atoi(fields[0])
+			if err != nil {
+				fnErr("CLOSE", fmt.Errorf("bad status code %q: %w", fields[0], err))
+				return
+			}
+			code = n
+			reason = strings.Join(fields[1:], " ")
+		}
+		pf.closeWaitWithCode(code, reason)
+
 	// dial
 	case bytes.HasPrefix(line, []byte("\\dial ws")):
 
@@ -161,16 +422,124 @@ func (pf *fsm) processLine(line []byte) {
 			return
 		}
 		pf.closeWait()
-		pC, wsRsp, err := Dial(string(line), pf.hdr)
+		pf.mtxPH.Lock()
+		err := pf.connect(string(line))
+		pf.mtxPH.Unlock()
 		if err != nil {
-			fnErr("WS DIAL ["+wsRsp.Status+"]", err)
+			fnErr("WS DIAL", err)
 			return
 		}
-		pf.pH, err = StartHandler(pC, 10*time.Second, 0, 0, pf.PrintMsg)
-		if err != nil {
-			fnErr("WS HANDLER", err)
+		if pf.reconnectOn {
+			pf.startSupervisor()
+		}
+
+	// toggle/trigger reconnection
+	case bytes.HasPrefix(line, []byte("\\reconnect")):
+		switch arg := string(bytes.TrimSpace(bytes.TrimPrefix(line, []byte("\\reconnect")))); arg {
+
+		case "on":
+			pf.reconnectOn = true
+			if pf.getPH() != nil {
+				pf.startSupervisor()
+			}
+			fmt.Print("\x1b[96m", "RECONNECT ON", "\x1b[0m\n")
+
+		case "off":
+			pf.reconnectOn = false
+			pf.stopSupervisor()
+			fmt.Print("\x1b[96m", "RECONNECT OFF", "\x1b[0m\n")
+
+		case "now":
+			pf.mtxPH.Lock()
+			szURL := pf.szDialURL
+			pf.mtxPH.Unlock()
+			if len(szURL) == 0 {
+				fnErr("RECONNECT", fmt.Errorf("no prior \\dial URL"))
+				return
+			}
+			pf.stopBridge()
+			chDone := pf.stopSupervisor()
+
+			pf.mtxPH.Lock()
+			pH := pf.pH
+			pf.pH = nil
+			pf.mtxPH.Unlock()
+
+			if pH != nil {
+				fnErr("ws close", pH.Close())
+			}
+			if chDone != nil {
+				<-chDone
+			} else if pH != nil {
+				pH.Wait()
+			}
+
+			pf.mtxPH.Lock()
+			err := pf.connect(pf.szDialURL)
+			pf.mtxPH.Unlock()
+			if err != nil {
+				fnErr("RECONNECT", err)
+				return
+			}
+			if pf.reconnectOn {
+				pf.startSupervisor()
+			}
+
+		default:
+			fmt.Print("\x1b[91m", "usage: \\reconnect on|off|now", "\x1b[0m\n")
+		}
+
+	// bridge websocket to child process stdio
+	case bytes.HasPrefix(line, []byte("\\bridge ")):
+
+		line = bytes.TrimPrefix(line, []byte("\\bridge "))
+		if line = bytes.TrimSpace(line); len(line) == 0 {
 			return
 		}
+		fields := strings.Fields(string(line))
+		if err := pf.startBridge(fields[0], fields[1:]); err != nil {
+			fnErr("BRIDGE", err)
+		}
+
+	// send an application-level ping immediately
+	case bytes.HasPrefix(line, []byte("\\ping")):
+		pH := pf.getPH()
+		if pH == nil {
+			return
+		}
+		if err := pH.PingFn(pH); err != nil {
+			fnErr("PING", err)
+		} else {
+			fmt.Print("\x1b[96m", "PING SENT", "\x1b[0m\n")
+		}
+
+	// toggle per-message write compression
+	case bytes.HasPrefix(line, []byte("\\compress")):
+		switch arg := string(bytes.TrimSpace(bytes.TrimPrefix(line, []byte("\\compress")))); arg {
+
+		case "on":
+			pf.mtxPH.Lock()
+			pf.compressOn = true
+			pH := pf.pH
+			pf.mtxPH.Unlock()
+			if pH != nil {
+				fnErr("COMPRESS", pH.SetCompression(true))
+			}
+			fmt.Print("\x1b[96m", "COMPRESS ON", "\x1b[0m\n")
+
+		case "off":
+			pf.mtxPH.Lock()
+			pf.compressOn = false
+			pH := pf.pH
+			pf.mtxPH.Unlock()
+			if pH != nil {
+				fnErr("COMPRESS", pH.SetCompression(false))
+			}
+			fmt.Print("\x1b[96m", "COMPRESS OFF", "\x1b[0m\n")
+
+		default:
+			fmt.Print("\x1b[91m", "usage: \\compress on|off", "\x1b[0m\n")
+		}
 
 	// add/remove HTTP headers
 	case len(line) > 0:
@@ -198,8 +567,32 @@ func (pf *fsm) processLine(line []byte) {
 	}
 }
 
+// ReadMsg dispatches inbound websocket messages: to the bridged child's
+// stdin when \bridge is active, otherwise to stdout via PrintMsg.
+func (pf *fsm) ReadMsg(pHdl *Handler, nType int, iRdr io.Reader, err error) bool {
+
+	pf.mtxPH.Lock()
+	pb := pf.pBridge
+	pf.mtxPH.Unlock()
+
+	if err != nil || pb == nil {
+		return pf.PrintMsg(pHdl, nType, iRdr, err)
+	}
+
+	bsMsg, err := io.ReadAll(iRdr)
+	if err != nil {
+		fnErr("websocket->bridge", err)
+		return false
+	}
+	if err := pb.writeIn(bsMsg); err != nil {
+		fnErr("bridge stdin", err)
+		return false
+	}
+	return true
+}
+
 // websocket -> stdout
-func (pf *fsm) PrintMsg(pHdl *Handler, _ int, iRdr io.Reader, err error) bool {
+func (pf *fsm) PrintMsg(pHdl *Handler, nType int, iRdr io.Reader, err error) bool {
 
 	// exit on websocket error
 	if err != nil {
@@ -236,6 +629,17 @@ func (pf *fsm) PrintMsg(pHdl *Handler, _ int, iRdr io.Reader, err error) bool {
 			fmt.Print("\x1b[92m")
 		}
 
+		// binary frames get a [BIN n bytes] header and are rendered per
+		// -binfmt instead of being dumped raw (which usually isn't useful
+		// on a terminal)
+		if nType == BinaryMessage && pf.binFmt != "raw" {
+			fmt.Printf("\x1b[95m[BIN %d bytes]\x1b[0m\n", len(bsMsg))
+			bsMsg = encodeBinMsg(pf.binFmt, bsMsg)
+			bsMsg = append(bsMsg, '\n')
+		} else if nType == BinaryMessage {
+			fmt.Printf("\x1b[95m[BIN %d bytes]\x1b[0m\n", len(bsMsg))
+		}
+
 		// raw output
 		if pf.logWri != nil {
 			pf.logWri.Write(bsMsg)
@@ -251,6 +655,19 @@ func (pf *fsm) PrintMsg(pHdl *Handler, _ int, iRdr io.Reader, err error) bool {
 	return true
 }
 
+// encodeBinMsg renders bsMsg per binFmt ("hex"|"base64"); any other value
+// passes bsMsg through unchanged.
+func encodeBinMsg(binFmt string, bsMsg []byte) []byte {
+	switch binFmt {
+	case "hex":
+		return []byte(hex.EncodeToString(bsMsg))
+	case "base64":
+		return []byte(base64.StdEncoding.EncodeToString(bsMsg))
+	default:
+		return bsMsg
+	}
+}
+
 func main() {
 
 	const helpPrefix = `wscli
@@ -265,6 +682,11 @@ COMMANDS
 	Disconnect from Websocket
 		\hup
 
+	Send a Proper Close Frame, Then Disconnect
+		\close [code] [reason]
+
+		(code defaults to 1000 (normal closure) if left unspecified)
+
 	Send Message (heredoc format)
 		\msg [message terminator]
 		message line 1
@@ -274,6 +696,15 @@ COMMANDS
 
 		(default terminator is a blank line if left unspecified)
 
+	Send Binary Message (heredoc format, body sent as-is)
+		\bmsg [message terminator]
+		message line 1
+		[message terminator]
+
+	Send Binary Message (heredoc body hex/base64-decoded before sending)
+		\bmsg-hex [message terminator]
+		\bmsg-b64 [message terminator]
+
 	Specify HTTP Headers
 		Authorization: awo875pu84uj6paj436up
 		Content-Type: application/json
@@ -287,6 +718,28 @@ COMMANDS
 	Clear All Specified HTTP Headers
 		\hdrclr
 
+	Bridge Websocket to a Child Process' Stdio
+		\bridge cat
+
+		(torn down on reconnect; re-issue \bridge afterward to resume it)
+
+	Register Message to Send After Every (Re)connect (heredoc format)
+		\onconnect \msg [message terminator]
+		message line 1
+		[message terminator]
+
+	Toggle or Trigger Reconnection
+		\reconnect on
+		\reconnect off
+		\reconnect now
+
+	Send an Application-Level Ping Immediately
+		\ping
+
+	Toggle Per-Message Write Compression
+		\compress on
+		\compress off
+
 `
 
 	// HELP MESSAGE
@@ -299,11 +752,30 @@ COMMANDS
 	}
 
 	fsm := newFsm()
-	var szLogPath string
+	var szLogPath, szBridge, szBridgeFraming string
 	flag.BoolVar(&fsm.printTs, "ts", false, "print message timestamps")
 	flag.StringVar(&szLogPath, "log", "-", "output log file")
+	flag.StringVar(&szBridge, "bridge", "", "spawn `cmd` and bridge its stdio to the websocket")
+	flag.StringVar(&szBridgeFraming, "bridge-framing", "line", "bridge child framing: line|null|size")
+	flag.BoolVar(&fsm.reconnectOn, "reconnect", false, "automatically reconnect with exponential backoff")
+	flag.IntVar(&fsm.reconnectOpts.MaxAttempts, "reconnect-max", 0, "max reconnect attempts (0 = unlimited)")
+	flag.StringVar(&fsm.pingText, "ping-text", "", "send this as a TextMessage ping instead of a control frame")
+	flag.StringVar(&fsm.pongText, "pong-text", "", "treat an inbound TextMessage with this payload as a pong")
+	flag.BoolVar(&fsm.compressOn, "compress", false, "negotiate permessage-deflate compression")
+	flag.IntVar(&fsm.compressLevel, "compress-level", 0, "per-message compression level (0 = library default)")
+	flag.Int64Var(&fsm.maxMsgSize, "max-msg", DefaultMaxMsgSize, "max inbound message size in bytes")
+	var szBinFmt string
+	flag.StringVar(&szBinFmt, "binfmt", "raw", "rendering of inbound binary frames: raw|hex|base64")
 	flag.Parse()
 
+	switch szBinFmt {
+	case "raw", "hex", "base64":
+		fsm.binFmt = szBinFmt
+	default:
+		fnErrAbort("binfmt", fmt.Errorf("unrecognized -binfmt %q", szBinFmt))
+		return
+	}
+
 	if szLogPath != "-" {
 		pfLog, eLog := os.OpenFile(szLogPath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0664)
 		if eLog != nil {
@@ -314,12 +786,22 @@ COMMANDS
 		defer pfLog.Close()
 	}
 
+	var err error
+	if fsm.bridgeFraming, err = parseBridgeFraming(szBridgeFraming); err != nil {
+		fnErrAbort("bridge framing", err)
+		return
+	}
+
 	buf := make([]byte, 4096)
 
 	if wsConn := flag.Arg(0); (len(wsConn) > 0) && strings.HasPrefix(wsConn, "ws") {
 		fsm.processLine([]byte("\\dial " + wsConn))
 	}
 
+	if len(szBridge) > 0 {
+		fsm.processLine([]byte("\\bridge " + szBridge))
+	}
+
 	for {
 
 		n, rdErr := os.Stdin.Read(buf)
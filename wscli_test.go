@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestEncodeBinMsg(t *testing.T) {
+	bsMsg := []byte("hi")
+	for _, tc := range []struct {
+		binFmt string
+		want   string
+	}{
+		{"hex", "6869"},
+		{"base64", "aGk="},
+		{"raw", "hi"},
+		{"unknown", "hi"},
+	} {
+		got := string(encodeBinMsg(tc.binFmt, bsMsg))
+		if got != tc.want {
+			t.Errorf("encodeBinMsg(%q, %q) = %q, want %q", tc.binFmt, bsMsg, got, tc.want)
+		}
+	}
+}